@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// ConfigCacheSize is the configuration field name for the max number
+	// of resolved hub resources to keep in the in-memory cache.
+	ConfigCacheSize = "cache-size"
+
+	// ConfigCacheTTL is the configuration field name for how long a
+	// cached resource remains fresh, expressed as a Go duration string
+	// (e.g. "10m").
+	ConfigCacheTTL = "cache-ttl"
+
+	// ConfigCacheServeStale is the configuration field name that, when
+	// "true", allows a stale cache entry to be served if refreshing it
+	// from the hub fails.
+	ConfigCacheServeStale = "cache-serve-stale"
+
+	// AnnotationStale is set to "true" on a ResolvedHubResource that was
+	// served from a stale cache entry because the hub couldn't be reached.
+	AnnotationStale = "resolution.tekton.dev/stale"
+
+	defaultCacheSize = 100
+	defaultCacheTTL  = 5 * time.Minute
+)
+
+// cacheEntry is what's stored in the hub cache for a single resolved
+// resource.
+type cacheEntry struct {
+	content    []byte
+	sourceURL  string
+	digest     string
+	entryPoint string
+	fetchedAt  time.Time
+}
+
+// versionCacheEntry is what's stored in the hub cache for the outcome of
+// resolving a semver constraint (e.g. "^0.6") to a concrete version, so a
+// warm cache can skip the versions-list round trip entirely and so
+// cache-serve-stale has a last-known-good version to fall back on.
+type versionCacheEntry struct {
+	version   string
+	fetchedAt time.Time
+}
+
+// hubCache is a bounded, TTL-aware cache of hub fetches keyed on
+// (type, catalog, kind, name, resolved-version), plus a sub-cache of
+// constraint-to-resolved-version lookups sharing the same underlying LRU
+// and TTL.
+type hubCache struct {
+	lru  *lru.Cache
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu sync.Mutex
+}
+
+func newHubCache(size int, ttl time.Duration, now func() time.Time) (*hubCache, error) {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	l, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &hubCache{lru: l, size: size, ttl: ttl, now: now}, nil
+}
+
+// reconfigure applies the current hubresolver-config cache-size/cache-ttl
+// values to the cache, so edits to the configmap take effect without a
+// pod restart. Existing entries are kept, except that shrinking the
+// capacity evicts the least recently used entries beyond the new size.
+func (c *hubCache) reconfigure(size int, ttl time.Duration) {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if size != c.size {
+		c.lru.Resize(size)
+		c.size = size
+	}
+	c.ttl = ttl
+}
+
+// cacheKey builds the cache key for a resolved resource.
+func cacheKey(hubType, catalog, kind, name, version string) string {
+	return strings.Join([]string{"content", hubType, catalog, kind, name, version}, "/")
+}
+
+// versionCacheKey builds the cache key for a resolved constraint. It's
+// namespaced separately from cacheKey since both share the same LRU.
+func versionCacheKey(hubType, catalog, kind, name, versionConstraint string) string {
+	return strings.Join([]string{"version", hubType, catalog, kind, name, versionConstraint}, "/")
+}
+
+// get returns the entry stored at key, if any, and whether it's still
+// within its TTL.
+func (c *hubCache) get(key string) (entry cacheEntry, found bool, fresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.lru.Get(key)
+	if !ok {
+		return cacheEntry{}, false, false
+	}
+	entry = v.(cacheEntry)
+	fresh = c.now().Sub(entry.fetchedAt) < c.ttl
+	return entry, true, fresh
+}
+
+func (c *hubCache) put(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Add(key, entry)
+}
+
+// getVersion returns the resolved-version entry stored at key, if any,
+// and whether it's still within its TTL.
+func (c *hubCache) getVersion(key string) (entry versionCacheEntry, found bool, fresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.lru.Get(key)
+	if !ok {
+		return versionCacheEntry{}, false, false
+	}
+	entry = v.(versionCacheEntry)
+	fresh = c.now().Sub(entry.fetchedAt) < c.ttl
+	return entry, true, fresh
+}
+
+func (c *hubCache) putVersion(key string, entry versionCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Add(key, entry)
+}
+
+// cacheSizeFromConf returns the configured cache size, falling back to
+// defaultCacheSize if unset or invalid.
+func cacheSizeFromConf(conf map[string]string) int {
+	s, ok := conf[ConfigCacheSize]
+	if !ok {
+		return defaultCacheSize
+	}
+	size, err := strconv.Atoi(s)
+	if err != nil || size <= 0 {
+		return defaultCacheSize
+	}
+	return size
+}
+
+// cacheTTLFromConf returns the configured cache TTL, falling back to
+// defaultCacheTTL if unset or invalid.
+func cacheTTLFromConf(conf map[string]string) time.Duration {
+	t, ok := conf[ConfigCacheTTL]
+	if !ok {
+		return defaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(t)
+	if err != nil || ttl <= 0 {
+		return defaultCacheTTL
+	}
+	return ttl
+}
+
+// cacheServeStaleFromConf reports whether stale cache entries may be
+// served when a refresh fails.
+func cacheServeStaleFromConf(conf map[string]string) bool {
+	return conf[ConfigCacheServeStale] == "true"
+}
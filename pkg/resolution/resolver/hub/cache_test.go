@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHubCacheGetPut(t *testing.T) {
+	now := time.Now()
+	cache, err := newHubCache(10, time.Minute, func() time.Time { return now })
+	if err != nil {
+		t.Fatalf("newHubCache() returned error: %v", err)
+	}
+
+	key := cacheKey(TektonHubType, "tekton", "task", "git-clone", "0.6")
+	if _, found, _ := cache.get(key); found {
+		t.Fatal("get() found an entry before any put()")
+	}
+
+	entry := cacheEntry{content: []byte("kind: Task\n"), digest: "abc123", fetchedAt: now}
+	cache.put(key, entry)
+
+	got, found, fresh := cache.get(key)
+	if !found || !fresh {
+		t.Fatalf("get() = found=%v fresh=%v, want both true", found, fresh)
+	}
+	if string(got.content) != "kind: Task\n" {
+		t.Errorf("get() content = %q, want %q", got.content, "kind: Task\n")
+	}
+
+	// Advance past the TTL: the entry should still be found, but no
+	// longer fresh.
+	now = now.Add(2 * time.Minute)
+	_, found, fresh = cache.get(key)
+	if !found {
+		t.Fatal("get() after TTL expiry should still find the stale entry")
+	}
+	if fresh {
+		t.Error("get() after TTL expiry reported fresh=true")
+	}
+}
+
+func TestHubCacheReconfigure(t *testing.T) {
+	cache, err := newHubCache(2, time.Minute, time.Now)
+	if err != nil {
+		t.Fatalf("newHubCache() returned error: %v", err)
+	}
+
+	cache.put(cacheKey(TektonHubType, "tekton", "task", "a", "0.1"), cacheEntry{content: []byte("a")})
+	cache.put(cacheKey(TektonHubType, "tekton", "task", "b", "0.1"), cacheEntry{content: []byte("b")})
+
+	// Shrinking the cache should evict the oldest entry rather than
+	// error out, so a later config edit can take effect without a
+	// restart.
+	cache.reconfigure(1, 2*time.Minute)
+
+	if cache.ttl != 2*time.Minute {
+		t.Errorf("ttl after reconfigure = %v, want %v", cache.ttl, 2*time.Minute)
+	}
+	if _, found, _ := cache.get(cacheKey(TektonHubType, "tekton", "task", "a", "0.1")); found {
+		t.Error("expected the oldest entry to have been evicted after shrinking the cache")
+	}
+	if _, found, _ := cache.get(cacheKey(TektonHubType, "tekton", "task", "b", "0.1")); !found {
+		t.Error("expected the newest entry to survive shrinking the cache")
+	}
+}
+
+func TestCacheConfFromConf(t *testing.T) {
+	if got := cacheSizeFromConf(nil); got != defaultCacheSize {
+		t.Errorf("cacheSizeFromConf(nil) = %d, want default %d", got, defaultCacheSize)
+	}
+	if got := cacheSizeFromConf(map[string]string{ConfigCacheSize: "42"}); got != 42 {
+		t.Errorf("cacheSizeFromConf() = %d, want %d", got, 42)
+	}
+	if got := cacheSizeFromConf(map[string]string{ConfigCacheSize: "not-a-number"}); got != defaultCacheSize {
+		t.Errorf("cacheSizeFromConf() with invalid value = %d, want default %d", got, defaultCacheSize)
+	}
+
+	if got := cacheTTLFromConf(nil); got != defaultCacheTTL {
+		t.Errorf("cacheTTLFromConf(nil) = %v, want default %v", got, defaultCacheTTL)
+	}
+	if got := cacheTTLFromConf(map[string]string{ConfigCacheTTL: "10m"}); got != 10*time.Minute {
+		t.Errorf("cacheTTLFromConf() = %v, want %v", got, 10*time.Minute)
+	}
+
+	if cacheServeStaleFromConf(nil) {
+		t.Error("cacheServeStaleFromConf(nil) = true, want false")
+	}
+	if !cacheServeStaleFromConf(map[string]string{ConfigCacheServeStale: "true"}) {
+		t.Error("cacheServeStaleFromConf() = false, want true")
+	}
+}
+
+func TestGetCacheAppliesLiveConfig(t *testing.T) {
+	r := &Resolver{now: time.Now}
+
+	c1 := r.getCache(map[string]string{ConfigCacheSize: "5", ConfigCacheTTL: "1m"})
+	if c1.ttl != time.Minute {
+		t.Fatalf("initial ttl = %v, want %v", c1.ttl, time.Minute)
+	}
+
+	// A later call with a different config should reconfigure the same
+	// cache instance in place, rather than requiring a restart.
+	c2 := r.getCache(map[string]string{ConfigCacheSize: "5", ConfigCacheTTL: "30m"})
+	if c1 != c2 {
+		t.Fatal("getCache() returned a different cache instance on the second call")
+	}
+	if c2.ttl != 30*time.Minute {
+		t.Errorf("ttl after config change = %v, want %v", c2.ttl, 30*time.Minute)
+	}
+}
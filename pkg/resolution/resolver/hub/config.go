@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+const (
+	// ConfigCatalog is the configuration field name for controlling
+	// the default hub catalog that's used.
+	ConfigCatalog = "default-catalog"
+
+	// ConfigKind is the configuration field name for controlling
+	// the default kind of resource that's fetched.
+	ConfigKind = "default-kind"
+
+	// ConfigType is the configuration field name for controlling
+	// which hub implementation is used by default when a request
+	// doesn't supply its own type param.
+	ConfigType = "default-type"
+
+	// ConfigExpectedDigest is the configuration field name for an
+	// installation-wide default sha256 digest to pin resolved resources
+	// to, used when a request doesn't supply its own digest param. This
+	// only makes sense for an installation that resolves a single,
+	// known resource by default; most installs leave it unset and pin
+	// per-request via ParamDigest instead.
+	ConfigExpectedDigest = "default-digest"
+
+	// ConfigTektonHubURL is the configuration field name for overriding
+	// the Tekton Hub API URL template used to fetch resources.
+	ConfigTektonHubURL = "tekton-hub-url"
+
+	// ConfigArtifactHubURL is the configuration field name for overriding
+	// the Artifact Hub API URL template used to fetch resources.
+	ConfigArtifactHubURL = "artifact-hub-url"
+
+	// ConfigTektonHubVersionsURL is the configuration field name for
+	// the Tekton Hub API URL template used to list the available
+	// versions of a resource, used when version is a semver constraint
+	// rather than an exact version. It has no default: resolving a
+	// constraint against Tekton Hub fails when it's unset.
+	ConfigTektonHubVersionsURL = "tekton-hub-versions-url"
+
+	// ConfigArtifactHubVersionsURL is the configuration field name for
+	// the Artifact Hub API URL template used to list the available
+	// versions of a resource, used when version is a semver constraint
+	// rather than an exact version. It has no default: resolving a
+	// constraint against Artifact Hub fails when it's unset.
+	ConfigArtifactHubVersionsURL = "artifact-hub-versions-url"
+)
+
+const (
+	// ParamName is the parameter defining what the name of the resource
+	// to fetch is.
+	ParamName = "name"
+
+	// ParamVersion is the parameter defining what version of the
+	// resource to fetch.
+	ParamVersion = "version"
+
+	// ParamCatalog is the parameter defining which catalog to fetch
+	// the resource from.
+	ParamCatalog = "catalog"
+
+	// ParamKind is the parameter defining what resource kind to fetch,
+	// currently only pipeline and task are supported.
+	ParamKind = "kind"
+
+	// ParamType is the parameter defining which hub to resolve the
+	// resource from. Supported values are "tekton" and "artifact".
+	ParamType = "type"
+
+	// ParamDigest is the parameter that, when set, pins the resolved
+	// resource to a known sha256 digest of its YAML content. Resolve
+	// fails if the fetched resource doesn't match.
+	ParamDigest = "digest"
+)
+
+const (
+	// TektonHubType is the value of the type param (or ConfigType) that
+	// resolves resources against Tekton Hub.
+	TektonHubType = "tekton"
+
+	// ArtifactHubType is the value of the type param (or ConfigType) that
+	// resolves resources against Artifact Hub.
+	ArtifactHubType = "artifact"
+)
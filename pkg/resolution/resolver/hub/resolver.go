@@ -15,11 +15,15 @@ package hub
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	resolverconfig "github.com/tektoncd/pipeline/pkg/apis/config/resolver"
 	pipelinev1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -37,12 +41,25 @@ const (
 
 // Resolver implements a framework.Resolver that can fetch files from OCI bundles.
 type Resolver struct {
-	// HubURL is the URL for hub resolver
+	// HubURL is the URL for the Tekton Hub resolver
 	HubURL string
+
+	// ArtifactHubURL is the URL for the Artifact Hub resolver
+	ArtifactHubURL string
+
+	// now returns the current time and exists so tests can inject a
+	// fake clock; it's wired up in Initialize.
+	now func() time.Time
+
+	cache     *hubCache
+	cacheOnce sync.Once
 }
 
-// Initialize sets up any dependencies needed by the resolver. None atm.
+// Initialize sets up any dependencies needed by the resolver.
 func (r *Resolver) Initialize(context.Context) error {
+	if r.now == nil {
+		r.now = time.Now
+	}
 	return nil
 }
 
@@ -78,11 +95,19 @@ func (r *Resolver) ValidateParams(ctx context.Context, params []pipelinev1beta1.
 	if _, ok := paramsMap[ParamVersion]; !ok {
 		return errors.New("must include version param")
 	}
+	// version may be an exact version or a semver constraint (e.g.
+	// "^0.6", ">=0.4 <0.7", "latest"); the constraint itself can't be
+	// validated without a network call, so it's checked in Resolve.
 	if kind, ok := paramsMap[ParamKind]; ok {
 		if kind.StringVal != "task" && kind.StringVal != "pipeline" {
 			return errors.New("kind param must be task or pipeline")
 		}
 	}
+	if hubType, ok := paramsMap[ParamType]; ok {
+		if hubType.StringVal != TektonHubType && hubType.StringVal != ArtifactHubType {
+			return fmt.Errorf("type param must be %s or %s", TektonHubType, ArtifactHubType)
+		}
+	}
 	return nil
 }
 
@@ -94,6 +119,13 @@ type hubResponse struct {
 	Data dataResponse `json:"data"`
 }
 
+// artifactHubResponse models the subset of the Artifact Hub package
+// response (/api/v1/packages/tekton-{kind}/{repo}/{name}/{version}) that
+// we need: a URL pointing at the raw YAML manifest for the package.
+type artifactHubResponse struct {
+	ContentURL string `json:"content_url"`
+}
+
 // Resolve uses the given params to resolve the requested file or resource.
 func (r *Resolver) Resolve(ctx context.Context, params []pipelinev1beta1.Param) (framework.ResolvedResource, error) {
 	if r.isDisabled(ctx) {
@@ -126,37 +158,213 @@ func (r *Resolver) Resolve(ctx context.Context, params []pipelinev1beta1.Param)
 	if kind != "task" && kind != "pipeline" {
 		return nil, fmt.Errorf("kind param must be task or pipeline")
 	}
-
 	paramsMap[ParamKind] = kind
-	url := fmt.Sprintf(r.HubURL, paramsMap[ParamCatalog], paramsMap[ParamKind], paramsMap[ParamName], paramsMap[ParamVersion])
+
+	hubType, ok := paramsMap[ParamType]
+	if !ok {
+		if typeString, ok := conf[ConfigType]; ok {
+			hubType = typeString
+		} else {
+			hubType = TektonHubType
+		}
+	}
+	if hubType != TektonHubType && hubType != ArtifactHubType {
+		return nil, fmt.Errorf("type param must be %s or %s", TektonHubType, ArtifactHubType)
+	}
+
+	cache := r.getCache(conf)
+	serveStale := cacheServeStaleFromConf(conf)
+
+	resolvedVersion, versionStale, err := r.resolveVersion(conf, hubType, paramsMap, cache, serveStale)
+	if err != nil {
+		return nil, err
+	}
+	paramsMap[ParamVersion] = resolvedVersion
+
+	key := cacheKey(hubType, paramsMap[ParamCatalog], paramsMap[ParamKind], paramsMap[ParamName], resolvedVersion)
+
+	cached, found, fresh := cache.get(key)
+	entry := cacheEntry{}
+	stale := versionStale
+	if found && fresh {
+		entry = cached
+	} else {
+		urlTemplate, err := r.getHubURL(conf, hubType)
+		if err != nil {
+			return nil, err
+		}
+
+		var content []byte
+		var sourceURL string
+		switch hubType {
+		case ArtifactHubType:
+			content, sourceURL, err = fetchArtifactHubResource(urlTemplate, paramsMap)
+		default:
+			content, sourceURL, err = fetchTektonHubResource(urlTemplate, paramsMap)
+		}
+		if err != nil {
+			if found && serveStale {
+				entry = cached
+				stale = true
+			} else {
+				return nil, err
+			}
+		} else {
+			digest := sha256.Sum256(content)
+			entry = cacheEntry{
+				content:    content,
+				sourceURL:  sourceURL,
+				digest:     hex.EncodeToString(digest[:]),
+				entryPoint: fmt.Sprintf("%s/%s", paramsMap[ParamKind], paramsMap[ParamName]),
+				fetchedAt:  r.now(),
+			}
+			cache.put(key, entry)
+		}
+	}
+
+	if err := verifyDigest(paramsMap, conf, entry.digest); err != nil {
+		return nil, err
+	}
+
+	return &ResolvedHubResource{
+		Content:    entry.content,
+		SourceURL:  entry.sourceURL,
+		Digest:     entry.digest,
+		EntryPoint: entry.entryPoint,
+		Stale:      stale,
+	}, nil
+}
+
+// verifyDigest checks the fetched digest against the expected digest
+// supplied via ParamDigest, falling back to the installation-wide
+// ConfigExpectedDigest default. It's a no-op when neither is set.
+func verifyDigest(paramsMap, conf map[string]string, digest string) error {
+	expectedDigest, ok := paramsMap[ParamDigest]
+	if !ok {
+		expectedDigest, ok = conf[ConfigExpectedDigest]
+	}
+	if ok && expectedDigest != digest {
+		return fmt.Errorf("requested resource '%s' does not match the expected digest: expected sha256:%s, got sha256:%s", paramsMap[ParamName], expectedDigest, digest)
+	}
+	return nil
+}
+
+// getCache returns the resolver's hub cache, creating it on first use and
+// re-applying the current cache-size/cache-ttl from conf on every call so
+// that edits to hubresolver-config take effect without a restart.
+// cache-serve-stale is read fresh per call by its own caller and doesn't
+// need to be baked into the cache.
+func (r *Resolver) getCache(conf map[string]string) *hubCache {
+	size := cacheSizeFromConf(conf)
+	ttl := cacheTTLFromConf(conf)
+	r.cacheOnce.Do(func() {
+		r.cache, _ = newHubCache(size, ttl, r.now)
+	})
+	r.cache.reconfigure(size, ttl)
+	return r.cache
+}
+
+// getHubURL returns the URL template to use for the given hub type,
+// preferring an override from the hubresolver-config configmap over the
+// value the resolver was installed with.
+func (r *Resolver) getHubURL(conf map[string]string, hubType string) (string, error) {
+	switch hubType {
+	case ArtifactHubType:
+		if urlTemplate, ok := conf[ConfigArtifactHubURL]; ok {
+			return urlTemplate, nil
+		}
+		if r.ArtifactHubURL != "" {
+			return r.ArtifactHubURL, nil
+		}
+		return "", fmt.Errorf("default Artifact Hub URL was not set during installation of the hub resolver")
+	default:
+		if urlTemplate, ok := conf[ConfigTektonHubURL]; ok {
+			return urlTemplate, nil
+		}
+		if r.HubURL != "" {
+			return r.HubURL, nil
+		}
+		return "", fmt.Errorf("default Tekton Hub URL was not set during installation of the hub resolver")
+	}
+}
+
+// fetchTektonHubResource fetches a resource from Tekton Hub, whose
+// /v1/resource/.../yaml endpoint returns the YAML inline in the response
+// body. The returned source URL is the resource URL itself, since that's
+// what ultimately served the YAML.
+func fetchTektonHubResource(urlTemplate string, paramsMap map[string]string) ([]byte, string, error) {
+	url := fmt.Sprintf(urlTemplate, paramsMap[ParamCatalog], paramsMap[ParamKind], paramsMap[ParamName], paramsMap[ParamVersion])
+	body, err := getURL(url)
+	if err != nil {
+		return nil, "", err
+	}
+	hr := hubResponse{}
+	if err := json.Unmarshal(body, &hr); err != nil {
+		return nil, "", fmt.Errorf("error unmarshalling json response: %w", err)
+	}
+	return []byte(hr.Data.YAML), url, nil
+}
+
+// fetchArtifactHubResource fetches a resource from Artifact Hub, whose
+// package endpoint returns metadata pointing at a content_url that must be
+// followed to retrieve the raw YAML manifest. The returned source URL is
+// that content_url, since that's what ultimately served the YAML.
+func fetchArtifactHubResource(urlTemplate string, paramsMap map[string]string) ([]byte, string, error) {
+	url := fmt.Sprintf(urlTemplate, paramsMap[ParamKind], paramsMap[ParamCatalog], paramsMap[ParamName], paramsMap[ParamVersion])
+	body, err := getURL(url)
+	if err != nil {
+		return nil, "", err
+	}
+	ar := artifactHubResponse{}
+	if err := json.Unmarshal(body, &ar); err != nil {
+		return nil, "", fmt.Errorf("error unmarshalling json response: %w", err)
+	}
+	if ar.ContentURL == "" {
+		return nil, "", fmt.Errorf("artifact hub package '%s' did not include a content_url", url)
+	}
+	content, err := getURL(ar.ContentURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, ar.ContentURL, nil
+}
+
+// getURL performs a GET request against url and returns the response body.
+func getURL(url string) ([]byte, error) {
 	// #nosec G107 -- URL cannot be constant in this case.
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("error requesting resource from hub: %w", err)
 	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("requested resource '%s' not found on hub", url)
-	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("requested resource '%s' not found on hub", url)
+	}
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
-	hr := hubResponse{}
-	err = json.Unmarshal(body, &hr)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling json response: %w", err)
-	}
-	return &ResolvedHubResource{
-		Content: []byte(hr.Data.YAML),
-	}, nil
+	return body, nil
 }
 
 // ResolvedHubResource wraps the data we want to return to Pipelines
 type ResolvedHubResource struct {
 	Content []byte
+
+	// SourceURL is the upstream hub URL that ultimately served Content.
+	SourceURL string
+
+	// Digest is the sha256 digest of Content, hex-encoded.
+	Digest string
+
+	// EntryPoint is the resolved resource's kind/name pair, e.g. "task/git-clone".
+	EntryPoint string
+
+	// Stale is true when Content was served from a stale cache entry
+	// because the hub couldn't be reached.
+	Stale bool
 }
 
 var _ framework.ResolvedResource = &ResolvedHubResource{}
@@ -166,15 +374,27 @@ func (rr *ResolvedHubResource) Data() []byte {
 	return rr.Content
 }
 
-// Annotations returns any metadata needed alongside the data. None atm.
-func (*ResolvedHubResource) Annotations() map[string]string {
-	return nil
+// Annotations returns any metadata needed alongside the data. Only set
+// when the resource was served from a stale cache entry.
+func (rr *ResolvedHubResource) Annotations() map[string]string {
+	if !rr.Stale {
+		return nil
+	}
+	return map[string]string{
+		AnnotationStale: "true",
+	}
 }
 
 // Source is the source reference of the remote data that records where the remote
 // file came from including the url, digest and the entrypoint.
 func (rr *ResolvedHubResource) Source() *pipelinev1beta1.ConfigSource {
-	return nil
+	return &pipelinev1beta1.ConfigSource{
+		URI: rr.SourceURL,
+		Digest: map[string]string{
+			"sha256": rr.Digest,
+		},
+		EntryPoint: rr.EntryPoint,
+	}
 }
 
 func (r *Resolver) isDisabled(ctx context.Context) bool {
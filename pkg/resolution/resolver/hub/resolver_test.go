@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	pipelinev1beta1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+func TestFetchTektonHubResource(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"data":{"yaml":"kind: Task\nmetadata:\n  name: git-clone\n"}}`)
+	}))
+	defer svr.Close()
+
+	urlTemplate := svr.URL + "/v1/resource/%s/%s/%s/%s/yaml"
+	paramsMap := map[string]string{
+		ParamCatalog: "tekton", ParamKind: "task", ParamName: "git-clone", ParamVersion: "0.6",
+	}
+
+	content, sourceURL, err := fetchTektonHubResource(urlTemplate, paramsMap)
+	if err != nil {
+		t.Fatalf("fetchTektonHubResource() returned error: %v", err)
+	}
+	if string(content) != "kind: Task\nmetadata:\n  name: git-clone\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	wantURL := fmt.Sprintf(urlTemplate, "tekton", "task", "git-clone", "0.6")
+	if sourceURL != wantURL {
+		t.Errorf("sourceURL = %q, want %q", sourceURL, wantURL)
+	}
+}
+
+func TestFetchArtifactHubResource(t *testing.T) {
+	var contentURL string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/raw/git-clone.yaml":
+			fmt.Fprint(w, "kind: Task\nmetadata:\n  name: git-clone\n")
+		default:
+			fmt.Fprintf(w, `{"content_url":%q}`, contentURL)
+		}
+	}))
+	defer svr.Close()
+	contentURL = svr.URL + "/raw/git-clone.yaml"
+
+	urlTemplate := svr.URL + "/api/v1/packages/tekton-%s/%s/%s/%s"
+	paramsMap := map[string]string{
+		ParamCatalog: "community", ParamKind: "task", ParamName: "git-clone", ParamVersion: "0.6",
+	}
+
+	content, sourceURL, err := fetchArtifactHubResource(urlTemplate, paramsMap)
+	if err != nil {
+		t.Fatalf("fetchArtifactHubResource() returned error: %v", err)
+	}
+	if string(content) != "kind: Task\nmetadata:\n  name: git-clone\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	if sourceURL != contentURL {
+		t.Errorf("sourceURL = %q, want %q", sourceURL, contentURL)
+	}
+}
+
+func TestFetchArtifactHubResource_MissingContentURL(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{}`)
+	}))
+	defer svr.Close()
+
+	urlTemplate := svr.URL + "/api/v1/packages/tekton-%s/%s/%s/%s"
+	paramsMap := map[string]string{
+		ParamCatalog: "community", ParamKind: "task", ParamName: "git-clone", ParamVersion: "0.6",
+	}
+
+	if _, _, err := fetchArtifactHubResource(urlTemplate, paramsMap); err == nil {
+		t.Fatal("fetchArtifactHubResource() expected an error for a missing content_url, got none")
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		paramsMap map[string]string
+		conf      map[string]string
+		digest    string
+		wantErr   bool
+	}{
+		{
+			name:      "no expectation set",
+			paramsMap: map[string]string{ParamName: "git-clone"},
+			digest:    "abc123",
+		},
+		{
+			name:      "param digest matches",
+			paramsMap: map[string]string{ParamName: "git-clone", ParamDigest: "abc123"},
+			digest:    "abc123",
+		},
+		{
+			name:      "param digest mismatch",
+			paramsMap: map[string]string{ParamName: "git-clone", ParamDigest: "abc123"},
+			digest:    "def456",
+			wantErr:   true,
+		},
+		{
+			name:      "config default digest matches",
+			paramsMap: map[string]string{ParamName: "git-clone"},
+			conf:      map[string]string{ConfigExpectedDigest: "abc123"},
+			digest:    "abc123",
+		},
+		{
+			name:      "config default digest mismatch",
+			paramsMap: map[string]string{ParamName: "git-clone"},
+			conf:      map[string]string{ConfigExpectedDigest: "abc123"},
+			digest:    "def456",
+			wantErr:   true,
+		},
+		{
+			name:      "param digest takes precedence over config default",
+			paramsMap: map[string]string{ParamName: "git-clone", ParamDigest: "abc123"},
+			conf:      map[string]string{ConfigExpectedDigest: "def456"},
+			digest:    "abc123",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyDigest(tc.paramsMap, tc.conf, tc.digest)
+			if tc.wantErr && err == nil {
+				t.Fatal("verifyDigest() expected an error, got none")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("verifyDigest() returned unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolvedHubResourceSource(t *testing.T) {
+	rr := &ResolvedHubResource{
+		Content:    []byte("kind: Task\n"),
+		SourceURL:  "https://hub.tekton.dev/v1/resource/tekton/task/git-clone/0.6/yaml",
+		Digest:     "6f1ed002ab5595859014ebf0951522d9",
+		EntryPoint: "task/git-clone",
+	}
+	got := rr.Source()
+	want := &pipelinev1beta1.ConfigSource{
+		URI:        "https://hub.tekton.dev/v1/resource/tekton/task/git-clone/0.6/yaml",
+		Digest:     map[string]string{"sha256": "6f1ed002ab5595859014ebf0951522d9"},
+		EntryPoint: "task/git-clone",
+	}
+	if got.URI != want.URI || got.Digest["sha256"] != want.Digest["sha256"] || got.EntryPoint != want.EntryPoint {
+		t.Errorf("Source() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolvedHubResourceAnnotations(t *testing.T) {
+	fresh := &ResolvedHubResource{}
+	if got := fresh.Annotations(); got != nil {
+		t.Errorf("Annotations() for a fresh resource = %v, want nil", got)
+	}
+
+	stale := &ResolvedHubResource{Stale: true}
+	got := stale.Annotations()
+	if got[AnnotationStale] != "true" {
+		t.Errorf("Annotations() for a stale resource = %v, want %s=true", got, AnnotationStale)
+	}
+}
+
+func TestGetHubURL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		r       Resolver
+		conf    map[string]string
+		hubType string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "tekton falls back to struct field",
+			r:       Resolver{HubURL: "https://tekton/%s/%s/%s/%s"},
+			hubType: TektonHubType,
+			want:    "https://tekton/%s/%s/%s/%s",
+		},
+		{
+			name:    "tekton config overrides struct field",
+			r:       Resolver{HubURL: "https://tekton/%s/%s/%s/%s"},
+			conf:    map[string]string{ConfigTektonHubURL: "https://other-tekton/%s/%s/%s/%s"},
+			hubType: TektonHubType,
+			want:    "https://other-tekton/%s/%s/%s/%s",
+		},
+		{
+			name:    "tekton missing everywhere errors",
+			hubType: TektonHubType,
+			wantErr: true,
+		},
+		{
+			name:    "artifact from config",
+			conf:    map[string]string{ConfigArtifactHubURL: "https://artifact/%s/%s/%s/%s"},
+			hubType: ArtifactHubType,
+			want:    "https://artifact/%s/%s/%s/%s",
+		},
+		{
+			name:    "artifact missing everywhere errors",
+			hubType: ArtifactHubType,
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.r.getHubURL(tc.conf, tc.hubType)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("getHubURL() expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getHubURL() returned error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("getHubURL() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
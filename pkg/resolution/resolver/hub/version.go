@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// tektonHubVersionsResponse models the Tekton Hub
+// /v1/resource/{catalog}/{kind}/{name}/versions response.
+type tektonHubVersionsResponse struct {
+	Data struct {
+		Versions []struct {
+			Version string `json:"version"`
+		} `json:"versions"`
+	} `json:"data"`
+}
+
+// artifactHubVersionsResponse models the subset of the Artifact Hub
+// package response that lists the available versions of a package.
+type artifactHubVersionsResponse struct {
+	AvailableVersions []struct {
+		Version string `json:"version"`
+	} `json:"available_versions"`
+}
+
+// resolveVersion returns the concrete version to fetch for the given
+// version param, which may either be an exact version or a semver
+// constraint (e.g. "^0.6", ">=0.4 <0.7", "latest"). Exact versions are
+// returned unchanged without making any network calls.
+//
+// Constraints are resolved against cache: a fresh cached resolution skips
+// the versions-list round trip entirely, and if the round trip fails, a
+// stale cached resolution is served when serveStale is set. The bool
+// return reports whether the version served is stale.
+func (r *Resolver) resolveVersion(conf map[string]string, hubType string, paramsMap map[string]string, cache *hubCache, serveStale bool) (string, bool, error) {
+	version := paramsMap[ParamVersion]
+	if !isVersionConstraint(version) {
+		return version, false, nil
+	}
+
+	key := versionCacheKey(hubType, paramsMap[ParamCatalog], paramsMap[ParamKind], paramsMap[ParamName], version)
+	cached, found, fresh := cache.getVersion(key)
+	if found && fresh {
+		return cached.version, false, nil
+	}
+
+	resolved, err := r.listAndPickVersion(conf, hubType, paramsMap, version)
+	if err != nil {
+		if found && serveStale {
+			return cached.version, true, nil
+		}
+		return "", false, err
+	}
+
+	cache.putVersion(key, versionCacheEntry{version: resolved, fetchedAt: r.now()})
+	return resolved, false, nil
+}
+
+// listAndPickVersion calls the hub's list-versions endpoint and picks the
+// highest version satisfying constraint.
+func (r *Resolver) listAndPickVersion(conf map[string]string, hubType string, paramsMap map[string]string, constraint string) (string, error) {
+	versionsURLTemplate, err := r.getVersionsURL(conf, hubType)
+	if err != nil {
+		return "", err
+	}
+
+	var url string
+	switch hubType {
+	case ArtifactHubType:
+		url = fmt.Sprintf(versionsURLTemplate, paramsMap[ParamKind], paramsMap[ParamCatalog], paramsMap[ParamName])
+	default:
+		url = fmt.Sprintf(versionsURLTemplate, paramsMap[ParamCatalog], paramsMap[ParamKind], paramsMap[ParamName])
+	}
+
+	body, err := getURL(url)
+	if err != nil {
+		return "", err
+	}
+
+	versions, err := parseVersions(hubType, body)
+	if err != nil {
+		return "", err
+	}
+
+	return highestSatisfying(constraint, versions)
+}
+
+// getVersionsURL returns the URL template used to list the available
+// versions of a resource for the given hub type.
+func (r *Resolver) getVersionsURL(conf map[string]string, hubType string) (string, error) {
+	switch hubType {
+	case ArtifactHubType:
+		if urlTemplate, ok := conf[ConfigArtifactHubVersionsURL]; ok {
+			return urlTemplate, nil
+		}
+		return "", fmt.Errorf("Artifact Hub versions URL was not set during installation of the hub resolver")
+	default:
+		if urlTemplate, ok := conf[ConfigTektonHubVersionsURL]; ok {
+			return urlTemplate, nil
+		}
+		return "", fmt.Errorf("Tekton Hub versions URL was not set during installation of the hub resolver")
+	}
+}
+
+// isVersionConstraint reports whether version should be treated as a
+// semver constraint (e.g. "^0.6", ">=0.4 <0.7", "latest") rather than an
+// exact version. Tekton Hub catalog resources are conventionally
+// versioned with two components (e.g. "0.4"), so this uses the same
+// lenient semver.NewVersion that highestSatisfying uses for candidates,
+// rather than semver.StrictNewVersion which requires a full
+// major.minor.patch triplet and would misclassify those as constraints.
+func isVersionConstraint(version string) bool {
+	if version == "latest" {
+		return true
+	}
+	_, err := semver.NewVersion(strings.TrimPrefix(version, "v"))
+	return err != nil
+}
+
+// parseVersions decodes the versions listed in a hub's list-versions
+// response into a flat slice of version strings.
+func parseVersions(hubType string, body []byte) ([]string, error) {
+	var raw []string
+	switch hubType {
+	case ArtifactHubType:
+		avr := artifactHubVersionsResponse{}
+		if err := json.Unmarshal(body, &avr); err != nil {
+			return nil, fmt.Errorf("error unmarshalling json response: %w", err)
+		}
+		for _, v := range avr.AvailableVersions {
+			raw = append(raw, v.Version)
+		}
+	default:
+		tvr := tektonHubVersionsResponse{}
+		if err := json.Unmarshal(body, &tvr); err != nil {
+			return nil, fmt.Errorf("error unmarshalling json response: %w", err)
+		}
+		for _, v := range tvr.Data.Versions {
+			raw = append(raw, v.Version)
+		}
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("hub returned no versions")
+	}
+	return raw, nil
+}
+
+// highestSatisfying returns the highest version in versions that
+// satisfies constraintStr.
+func highestSatisfying(constraintStr string, versions []string) (string, error) {
+	if constraintStr == "latest" {
+		constraintStr = ">=0.0.0-0"
+	}
+	constraint, err := semver.NewConstraint(constraintStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraintStr, err)
+	}
+
+	var best *semver.Version
+	var bestRaw string
+	for _, raw := range versions {
+		v, err := semver.NewVersion(strings.TrimPrefix(raw, "v"))
+		if err != nil {
+			continue
+		}
+		if !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+			bestRaw = raw
+		}
+	}
+	if best == nil {
+		return "", fmt.Errorf("no version satisfying constraint %q was found", constraintStr)
+	}
+	return bestRaw, nil
+}
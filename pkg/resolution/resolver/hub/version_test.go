@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The Tekton Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsVersionConstraint(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		want    bool
+	}{
+		{"0.1", false},
+		{"0.4", false},
+		{"0.6", false},
+		{"v0.6", false},
+		{"1.2.3", false},
+		{"latest", true},
+		{"^0.6", true},
+		{">=0.4 <0.7", true},
+		{"~0.4", true},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			if got := isVersionConstraint(tc.version); got != tc.want {
+				t.Errorf("isVersionConstraint(%q) = %v, want %v", tc.version, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHighestSatisfying(t *testing.T) {
+	versions := []string{"0.1", "0.4", "0.5", "0.6", "0.6.1", "0.7"}
+
+	for _, tc := range []struct {
+		constraint string
+		want       string
+		wantErr    bool
+	}{
+		{constraint: "^0.6", want: "0.6.1"},
+		{constraint: ">=0.4 <0.6", want: "0.5"},
+		{constraint: "latest", want: "0.7"},
+		{constraint: "^2.0", wantErr: true},
+	} {
+		t.Run(tc.constraint, func(t *testing.T) {
+			got, err := highestSatisfying(tc.constraint, versions)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("highestSatisfying() expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("highestSatisfying() returned error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("highestSatisfying() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveVersion_ExactVersionSkipsNetwork(t *testing.T) {
+	r := &Resolver{now: time.Now}
+	cache, err := newHubCache(defaultCacheSize, defaultCacheTTL, r.now)
+	if err != nil {
+		t.Fatalf("newHubCache() returned error: %v", err)
+	}
+
+	paramsMap := map[string]string{ParamCatalog: "tekton", ParamKind: "task", ParamName: "git-clone", ParamVersion: "0.6"}
+	got, stale, err := r.resolveVersion(nil, TektonHubType, paramsMap, cache, false)
+	if err != nil {
+		t.Fatalf("resolveVersion() returned error: %v", err)
+	}
+	if stale {
+		t.Error("resolveVersion() reported stale for an exact version")
+	}
+	if got != "0.6" {
+		t.Errorf("resolveVersion() = %q, want %q", got, "0.6")
+	}
+}
+
+func TestResolveVersion_ConstraintCachedAfterFirstLookup(t *testing.T) {
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"data":{"versions":[{"version":"0.5"},{"version":"0.6"}]}}`)
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	r := &Resolver{now: func() time.Time { return now }}
+	cache, err := newHubCache(defaultCacheSize, defaultCacheTTL, r.now)
+	if err != nil {
+		t.Fatalf("newHubCache() returned error: %v", err)
+	}
+
+	conf := map[string]string{ConfigTektonHubVersionsURL: svr.URL + "/v1/resource/%s/%s/%s/versions"}
+	paramsMap := map[string]string{ParamCatalog: "tekton", ParamKind: "task", ParamName: "git-clone", ParamVersion: "^0.5"}
+
+	got, _, err := r.resolveVersion(conf, TektonHubType, paramsMap, cache, false)
+	if err != nil {
+		t.Fatalf("resolveVersion() returned error: %v", err)
+	}
+	if got != "0.5" {
+		t.Fatalf("resolveVersion() = %q, want %q", got, "0.5")
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call to the versions endpoint, got %d", calls)
+	}
+
+	// A second lookup for the same constraint should be served from the
+	// version cache without another round trip.
+	got, stale, err := r.resolveVersion(conf, TektonHubType, paramsMap, cache, false)
+	if err != nil {
+		t.Fatalf("resolveVersion() returned error on cache hit: %v", err)
+	}
+	if stale {
+		t.Error("resolveVersion() reported stale on a fresh cache hit")
+	}
+	if got != "0.5" {
+		t.Fatalf("resolveVersion() on cache hit = %q, want %q", got, "0.5")
+	}
+	if calls != 1 {
+		t.Fatalf("expected the versions endpoint to be called once total, got %d calls", calls)
+	}
+}
+
+func TestResolveVersion_ServesStaleVersionOnFailure(t *testing.T) {
+	up := true
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"versions":[{"version":"0.5"},{"version":"0.6"}]}}`)
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	r := &Resolver{now: func() time.Time { return now }}
+	// Use a one-minute TTL; it's expired below by advancing now past it.
+	cache, err := newHubCache(defaultCacheSize, time.Minute, r.now)
+	if err != nil {
+		t.Fatalf("newHubCache() returned error: %v", err)
+	}
+
+	conf := map[string]string{ConfigTektonHubVersionsURL: svr.URL + "/v1/resource/%s/%s/%s/versions"}
+	paramsMap := map[string]string{ParamCatalog: "tekton", ParamKind: "task", ParamName: "git-clone", ParamVersion: "^0.5"}
+
+	if _, _, err := r.resolveVersion(conf, TektonHubType, paramsMap, cache, false); err != nil {
+		t.Fatalf("resolveVersion() returned error priming the cache: %v", err)
+	}
+
+	// Expire the cache entry and take the hub down.
+	now = now.Add(2 * time.Minute)
+	up = false
+
+	if _, _, err := r.resolveVersion(conf, TektonHubType, paramsMap, cache, false); err == nil {
+		t.Fatal("resolveVersion() expected an error when cache-serve-stale is disabled and the hub is down")
+	}
+
+	got, stale, err := r.resolveVersion(conf, TektonHubType, paramsMap, cache, true)
+	if err != nil {
+		t.Fatalf("resolveVersion() with serveStale returned error: %v", err)
+	}
+	if !stale {
+		t.Error("resolveVersion() with serveStale expected stale=true")
+	}
+	if got != "0.6" {
+		t.Errorf("resolveVersion() with serveStale = %q, want last-known-good %q", got, "0.6")
+	}
+}